@@ -0,0 +1,151 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCallbacksFireInOrder checks that both the wildcard and specific key for
+// each lifecycle stage fire, in the documented order: before_event,
+// leave_state, enter_state, after_event, wildcard before specific within each
+// stage.
+func TestCallbacksFireInOrder(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	var got []string
+	record := func(name string) HookFunc {
+		return func(Event) error {
+			got = append(got, name)
+			return nil
+		}
+	}
+
+	sm, err := NewFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, Callbacks{
+		CallbackBeforeEvent:  record("before_*"),
+		BeforeEvent(EventGo): record("before_event"),
+		CallbackLeaveState:   record("leave_*"),
+		LeaveState(StateA):   record("leave_state"),
+		CallbackEnterState:   record("enter_*"),
+		EnterState(StateB):   record("enter_state"),
+		CallbackAfterEvent:   record("after_*"),
+		AfterEvent(EventGo):  record("after_event"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	if !sm.Emit(testEvent{t: EventGo}) {
+		t.Fatal("Emit failed")
+	}
+
+	want := []string{
+		"before_*", "before_event",
+		"leave_*", "leave_state",
+		"enter_*", "enter_state",
+		"after_*", "after_event",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// TestCallbacksBeforeEventCancelsTransition checks that a before_event hook
+// error stops the transition before anything has changed.
+func TestCallbacksBeforeEventCancelsTransition(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	wantErr := errors.New("no")
+	entered := false
+
+	sm, err := NewFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, Callbacks{
+		BeforeEvent(EventGo): func(Event) error { return wantErr },
+		EnterState(StateB):   func(Event) error { entered = true; return nil },
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	emitErr := sm.EmitErr(testEvent{t: EventGo})
+	var te *TransitionError
+	if !errors.As(emitErr, &te) || te.Kind != ErrKindCanceled || !errors.Is(te, wantErr) {
+		t.Fatalf("EmitErr = %v, want a canceled TransitionError wrapping %v", emitErr, wantErr)
+	}
+	if sm.CurrentState() != StateA {
+		t.Fatalf("CurrentState() = %v, want StateA: before_event error must not transition", sm.CurrentState())
+	}
+	if entered {
+		t.Fatal("enter_state fired despite before_event canceling the transition")
+	}
+}
+
+// TestCallbacksLeaveStateCancelsTransition checks that a leave_state hook
+// error also cancels the transition, same as before_event.
+func TestCallbacksLeaveStateCancelsTransition(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	wantErr := errors.New("stay")
+	entered := false
+
+	sm, err := NewFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, Callbacks{
+		LeaveState(StateA): func(Event) error { return wantErr },
+		EnterState(StateB): func(Event) error { entered = true; return nil },
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	emitErr := sm.EmitErr(testEvent{t: EventGo})
+	var te *TransitionError
+	if !errors.As(emitErr, &te) || te.Kind != ErrKindCanceled || !errors.Is(te, wantErr) {
+		t.Fatalf("EmitErr = %v, want a canceled TransitionError wrapping %v", emitErr, wantErr)
+	}
+	if sm.CurrentState() != StateA {
+		t.Fatalf("CurrentState() = %v, want StateA: leave_state error must not transition", sm.CurrentState())
+	}
+	if entered {
+		t.Fatal("enter_state fired despite leave_state canceling the transition")
+	}
+}
+
+// TestCallbacksEnterStateAndAfterEventErrorsDontCancel checks that, unlike
+// before_event/leave_state, enter_state and after_event hooks can't cancel a
+// transition: by the time they fire it has already committed.
+func TestCallbacksEnterStateAndAfterEventErrorsDontCancel(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	sm, err := NewFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, Callbacks{
+		EnterState(StateB):  func(Event) error { return errors.New("too late") },
+		AfterEvent(EventGo): func(Event) error { return errors.New("also too late") },
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	if err := sm.EmitErr(testEvent{t: EventGo}); err != nil {
+		t.Fatalf("EmitErr = %v, want nil: enter_state/after_event errors must not surface", err)
+	}
+	if sm.CurrentState() != StateB {
+		t.Fatalf("CurrentState() = %v, want StateB: transition already committed before enter_state/after_event fired", sm.CurrentState())
+	}
+}
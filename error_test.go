@@ -0,0 +1,45 @@
+package fsm
+
+import (
+	"errors"
+	"testing"
+)
+
+type wrappingEvent struct {
+	t       EventType
+	payload string
+}
+
+func (e wrappingEvent) Type() EventType     { return e.t }
+func (e wrappingEvent) Unwrap() interface{} { return e.payload }
+
+// TestTransitionErrorPreservesOriginalEvent ensures a failed transition's
+// error still lets callers recover the domain event via Unwrap(), not just
+// the bare EventType the generic core deals in.
+func TestTransitionErrorPreservesOriginalEvent(t *testing.T) {
+	const StateA StateNode = iota
+	const EventGo EventType = 1
+
+	sm, err := NewFSM(StateA, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	want := wrappingEvent{t: EventGo, payload: "order-42"}
+	emitErr := sm.EmitErr(want)
+	if emitErr == nil {
+		t.Fatal("expected an error for an event with no matching edge")
+	}
+
+	var te *TransitionError
+	if !errors.As(emitErr, &te) {
+		t.Fatalf("EmitErr error is %T, want *TransitionError", emitErr)
+	}
+	got, ok := te.OriginalEvent.(wrappingEvent)
+	if !ok {
+		t.Fatalf("OriginalEvent is %T, want wrappingEvent", te.OriginalEvent)
+	}
+	if got.Unwrap() != "order-42" {
+		t.Fatalf("OriginalEvent.Unwrap() = %v, want order-42", got.Unwrap())
+	}
+}
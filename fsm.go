@@ -2,11 +2,19 @@ package fsm
 
 import (
 	"errors"
+	"sort"
+	"strconv"
 )
 
 // StateNode represents a state node in FSM graph
 type StateNode int
 
+// AnyState is a sentinel From value for TransEdge: an edge with From ==
+// AnyState matches an event from whatever state the machine is currently
+// in, instead of listing every concrete source state. A concrete edge for
+// the same (state, event) pair always takes precedence over a wildcard one.
+const AnyState StateNode = -1
+
 // EventType represents an event that makes the state transfer from one to another
 type EventType int
 
@@ -33,74 +41,251 @@ type TransEdge struct {
 // state transformation occurs
 type Callback func(Event) bool
 
+// HookFunc is the signature of a lifecycle callback registered through
+// Callbacks. Returning a non-nil error cancels the in-flight transition;
+// this only has an effect for before_event and leave_state hooks, the other
+// two fire after the transition has already happened.
+type HookFunc func(Event) error
+
+// Callbacks holds looplab-style lifecycle hooks fired, in order, while Emit
+// processes an event: before_event, leave_state, enter_state and
+// after_event. Each bare key (e.g. CallbackBeforeEvent) fires for every
+// event/state; BeforeEvent, LeaveState, EnterState and AfterEvent build keys
+// that only fire for the given event/state.
+type Callbacks map[string]HookFunc
+
+const (
+	// CallbackBeforeEvent fires before any event is processed.
+	CallbackBeforeEvent = "before_*"
+	// CallbackLeaveState fires before leaving any state.
+	CallbackLeaveState = "leave_*"
+	// CallbackEnterState fires after entering any state.
+	CallbackEnterState = "enter_*"
+	// CallbackAfterEvent fires after any event has been processed.
+	CallbackAfterEvent = "after_*"
+)
+
+// BeforeEvent builds the callback key that fires before event e is processed.
+func BeforeEvent(e EventType) string { return beforeEventKeyG(e) }
+
+// LeaveState builds the callback key that fires before leaving state s.
+func LeaveState(s StateNode) string { return leaveStateKeyG(s) }
+
+// EnterState builds the callback key that fires after entering state s.
+func EnterState(s StateNode) string { return enterStateKeyG(s) }
+
+// AfterEvent builds the callback key that fires after event e has been
+// processed.
+func AfterEvent(e EventType) string { return afterEventKeyG(e) }
+
+// TransitionErrorKind classifies why Emit failed to make a transition.
+type TransitionErrorKind int
+
+const (
+	// ErrKindNoTransition means there's no edge for the current state/event pair.
+	ErrKindNoTransition TransitionErrorKind = iota
+	// ErrKindCanceled means a before_event, leave_state or edge Callback
+	// refused the transition.
+	ErrKindCanceled
+	// ErrKindAsync means a transition is already in progress. Reserved for
+	// the async-aware FSM variants.
+	ErrKindAsync
+)
+
+// TransitionError is returned by EmitErr when a transition doesn't happen.
+// Unlike the generic TransitionErrorG[EventType] it wraps, it also keeps the
+// original Event around, so callers can still recover domain data via
+// Event.Unwrap() instead of just the bare EventType the generic core deals
+// in.
+type TransitionError struct {
+	Kind TransitionErrorKind
+	// Event is the EventType of the event that failed to transition,
+	// carried over from the generic TransitionErrorG for compatibility.
+	Event EventType
+	Err   error
+	// OriginalEvent is the full Event passed to EmitErr, preserved so its
+	// Unwrap() is still reachable from the error.
+	OriginalEvent Event
+}
+
+func (e *TransitionError) Error() string {
+	return (&TransitionErrorG[EventType]{Kind: e.Kind, Event: e.Event, Err: e.Err}).Error()
+}
+
+func (e *TransitionError) Unwrap() error { return e.Err }
+
 // StateMachine ...
 type StateMachine interface {
 	// Emit emits an Event and makes the transformation occur, thread unsafe
 	// If state transfer successfully, return true, or return false
 	Emit(Event) bool
+	// EmitErr is like Emit but reports why a transition didn't happen as a
+	// *TransitionError instead of collapsing every failure into false.
+	EmitErr(Event) error
 	// CurrentState returns current state
 	CurrentState() StateNode
+	// Edges returns the transition table the machine was built from, mainly
+	// for introspection and visualization (see Visualize). AnyState edges
+	// appear already expanded into one concrete edge per state.
+	Edges() []TransEdge
+	// Close releases any background resources (e.g. state timeout timers,
+	// see NewFSMWithTimeout) held by the machine. It's a no-op for plain
+	// FSMs built with NewFSM/NewSafeFSM.
+	Close() error
+	// Snapshot serializes enough of the machine's state to be handed back
+	// to RestoreFSM later, so a long-lived FSM can survive a process
+	// restart. The transition table itself isn't part of the snapshot -
+	// RestoreFSM takes it the same way NewFSM does.
+	Snapshot() []byte
 }
 
+// StateChangeHook is called after every successful transition, so callers
+// can persist the new state to their own store (DB, KV, ...) without
+// polling CurrentState.
+type StateChangeHook func(from, to StateNode, event Event)
+
 // NewFSM is the constructor for a finite state machine
 // our fsm will start at `st` state. If ignore isn't nil,
-// it will be invoked when emit an unacceptable Event for current state
-func NewFSM(st StateNode, edges []TransEdge, ignore Callback) (StateMachine, error) {
-	m := &stateMachineImpl{
-		graph:   make(map[StateNode]map[EventType]stateInfo),
-		current: st,
-		ignore:  ignore,
+// it will be invoked when emit an unacceptable Event for current state.
+// callbacks registers the before/leave/enter/after lifecycle hooks fired
+// during Emit; it may be nil.
+//
+// onStateChange, if not nil, is called after every successful transition -
+// see StateChangeHook.
+//
+// NewFSM is a thin wrapper around NewGenericFSM[StateNode, EventType,
+// Event]; reach for the generic constructor directly if StateNode/EventType
+// int casts aren't a good fit for your domain.
+func NewFSM(st StateNode, edges []TransEdge, ignore Callback, callbacks Callbacks, onStateChange StateChangeHook) (StateMachine, error) {
+	edges, err := expandWildcardEdges(st, edges)
+	if err != nil {
+		return nil, err
 	}
-	for _, edge := range edges {
-		transTable, ok := m.graph[edge.From]
-		if !ok {
-			transTable = make(map[EventType]stateInfo)
-			m.graph[edge.From] = transTable
+
+	genericEdges := make([]Edge[StateNode, EventType, Event], len(edges))
+	for i, edge := range edges {
+		genericEdges[i] = Edge[StateNode, EventType, Event]{
+			From:     edge.From,
+			Event:    edge.Event,
+			To:       edge.To,
+			Callback: edge.Callback,
 		}
-		if _, ok := transTable[edge.Event]; ok {
-			return nil, errors.New("invalid fsm")
+	}
+	var genericIgnore func(EventType, Event) bool
+	if ignore != nil {
+		genericIgnore = func(_ EventType, e Event) bool { return ignore(e) }
+	}
+	genericCallbacks := make(CallbackSet[Event], len(callbacks))
+	for key, hook := range callbacks {
+		hook := hook
+		genericCallbacks[key] = func(e Event) error { return hook(e) }
+	}
+
+	inner, err := NewGenericFSM[StateNode, EventType, Event](st, genericEdges, genericIgnore, genericCallbacks)
+	if err != nil {
+		return nil, err
+	}
+	return &stateMachineImpl{inner: inner, onStateChange: onStateChange}, nil
+}
+
+// expandWildcardEdges replaces each AnyState edge with one concrete edge per
+// state the machine can be in - every From/To seen across concrete edges,
+// every wildcard's own To (so e.g. an "abort from anywhere" edge also
+// applies once the machine has reached the abort target itself), and the
+// initial state st - skipping any (state, event) pair a concrete edge
+// already covers, since a concrete edge always wins over a wildcard one.
+func expandWildcardEdges(st StateNode, edges []TransEdge) ([]TransEdge, error) {
+	concrete := make([]TransEdge, 0, len(edges))
+	wildcards := make(map[EventType]TransEdge)
+	states := map[StateNode]struct{}{st: {}}
+	for _, edge := range edges {
+		if edge.From == AnyState {
+			if _, dup := wildcards[edge.Event]; dup {
+				return nil, errors.New("invalid fsm")
+			}
+			wildcards[edge.Event] = edge
+			continue
 		}
-		transTable[edge.Event] = stateInfo{
-			state: edge.To,
-			cb:    edge.Callback,
+		concrete = append(concrete, edge)
+		states[edge.From] = struct{}{}
+		states[edge.To] = struct{}{}
+	}
+	if len(wildcards) == 0 {
+		return concrete, nil
+	}
+	for _, wildcard := range wildcards {
+		states[wildcard.To] = struct{}{}
+	}
+
+	covered := make(map[StateNode]map[EventType]struct{}, len(states))
+	for _, edge := range concrete {
+		transTable, ok := covered[edge.From]
+		if !ok {
+			transTable = make(map[EventType]struct{})
+			covered[edge.From] = transTable
 		}
+		transTable[edge.Event] = struct{}{}
 	}
-	return m, nil
-}
 
-type stateInfo struct {
-	state StateNode
-	cb    Callback
+	result := concrete
+	for state := range states {
+		for event, wildcard := range wildcards {
+			if _, ok := covered[state][event]; ok {
+				continue
+			}
+			result = append(result, TransEdge{From: state, Event: event, To: wildcard.To, Callback: wildcard.Callback})
+		}
+	}
+	return result, nil
 }
 
 type stateMachineImpl struct {
-	graph   map[StateNode]map[EventType]stateInfo
-	current StateNode
-	ignore  Callback
+	inner         *FSM[StateNode, EventType, Event]
+	onStateChange StateChangeHook
 }
 
 func (m *stateMachineImpl) Emit(e Event) bool {
-	t := e.Type()
-	nextState, ok := m.graph[m.current][t]
-	if !ok {
-		if m.ignore != nil {
-			m.ignore(e)
+	return m.EmitErr(e) == nil
+}
+
+func (m *stateMachineImpl) EmitErr(e Event) error {
+	from := m.inner.CurrentState()
+	err := m.inner.EmitErr(e.Type(), e)
+	if err == nil {
+		if m.onStateChange != nil {
+			m.onStateChange(from, m.inner.CurrentState(), e)
 		}
-		return false
+		return nil
 	}
-	state, allowTrans := nextState.state, nextState.cb
-	if allowTrans != nil {
-		if allowTrans(e) {
-			m.current = state
-		} else {
-			return false
-		}
-	} else {
-		m.current = state
+	var ge *TransitionErrorG[EventType]
+	if errors.As(err, &ge) {
+		return &TransitionError{Kind: ge.Kind, Event: ge.Event, Err: ge.Err, OriginalEvent: e}
 	}
-	return true
+	return err
 }
 
 func (m *stateMachineImpl) CurrentState() StateNode {
-	return m.current
+	return m.inner.CurrentState()
+}
+
+func (m *stateMachineImpl) Edges() []TransEdge {
+	genericEdges := m.inner.Edges()
+	edges := make([]TransEdge, len(genericEdges))
+	for i, ge := range genericEdges {
+		edges[i] = TransEdge{From: ge.From, Event: ge.Event, To: ge.To, Callback: ge.Callback}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].Event < edges[j].Event
+	})
+	return edges
+}
+
+// Close is a no-op: stateMachineImpl holds no background resources.
+func (m *stateMachineImpl) Close() error { return nil }
+
+func (m *stateMachineImpl) Snapshot() []byte {
+	return []byte(strconv.Itoa(int(m.CurrentState())))
 }
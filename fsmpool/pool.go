@@ -0,0 +1,137 @@
+// Package fsmpool manages many independent fsm.StateMachine instances keyed
+// by an identifier, for the common case of one FSM per entity - per-user,
+// per-order, per-connection - all sharing the same transition table.
+package fsmpool
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	fsm "github.com/caibirdme/fsm-go"
+)
+
+// numShards is the number of independently locked buckets a Pool splits its
+// machines across, so unrelated ids don't contend on the same mutex.
+const numShards = 32
+
+// Pool lazily builds one StateMachine per id, all from the same edges,
+// ignore and callbacks template, the first time that id is seen.
+type Pool[K comparable] struct {
+	shards    [numShards]*shard[K]
+	edges     []fsm.TransEdge
+	ignore    fsm.Callback
+	callbacks fsm.Callbacks
+	initial   func(K) fsm.StateNode
+}
+
+type shard[K comparable] struct {
+	mu  sync.RWMutex
+	sms map[K]fsm.StateMachine
+}
+
+// New creates a Pool. edges/ignore/callbacks are the shared transition table
+// template, used to build every machine exactly as NewSafeFSM would -
+// machines are safe to Emit on concurrently, since two goroutines may share
+// the same id. initial resolves the starting StateNode for a given id; if
+// nil, every machine starts at StateNode(0).
+func New[K comparable](edges []fsm.TransEdge, ignore fsm.Callback, callbacks fsm.Callbacks, initial func(K) fsm.StateNode) *Pool[K] {
+	p := &Pool[K]{edges: edges, ignore: ignore, callbacks: callbacks, initial: initial}
+	for i := range p.shards {
+		p.shards[i] = &shard[K]{sms: make(map[K]fsm.StateMachine)}
+	}
+	return p
+}
+
+func (p *Pool[K]) shardFor(id K) *shard[K] {
+	h := fnv.New32a()
+	fmt.Fprint(h, id)
+	return p.shards[h.Sum32()%numShards]
+}
+
+func (p *Pool[K]) getOrCreate(id K) (fsm.StateMachine, error) {
+	sh := p.shardFor(id)
+
+	sh.mu.RLock()
+	sm, ok := sh.sms[id]
+	sh.mu.RUnlock()
+	if ok {
+		return sm, nil
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sm, ok := sh.sms[id]; ok {
+		return sm, nil
+	}
+
+	var st fsm.StateNode
+	if p.initial != nil {
+		st = p.initial(id)
+	}
+	// NewSafeFSM, not NewFSM: Pool.Emit can be called for the same id from
+	// multiple goroutines concurrently, and only the map lookup above is
+	// guarded by sh.mu - the machine itself needs its own concurrency
+	// safety once retrieved.
+	sm, err := fsm.NewSafeFSM(st, p.edges, p.ignore, p.callbacks, nil)
+	if err != nil {
+		return nil, err
+	}
+	sh.sms[id] = sm
+	return sm, nil
+}
+
+// Emit emits e on id's machine, building it lazily from the pool's template
+// if this is the first time id is seen. Returns false if the machine
+// couldn't be built (an invalid template) or didn't transition.
+func (p *Pool[K]) Emit(id K, e fsm.Event) bool {
+	sm, err := p.getOrCreate(id)
+	if err != nil {
+		return false
+	}
+	return sm.Emit(e)
+}
+
+// CurrentState returns id's current state and true, or false if id has no
+// machine yet.
+func (p *Pool[K]) CurrentState(id K) (fsm.StateNode, bool) {
+	sh := p.shardFor(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	sm, ok := sh.sms[id]
+	if !ok {
+		return 0, false
+	}
+	return sm.CurrentState(), true
+}
+
+// Delete closes and removes id's machine, if any.
+func (p *Pool[K]) Delete(id K) {
+	sh := p.shardFor(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sm, ok := sh.sms[id]; ok {
+		sm.Close()
+		delete(sh.sms, id)
+	}
+}
+
+// Range calls f for every machine currently in the pool, stopping early if f
+// returns false. It takes a snapshot of each shard before calling f, so f
+// may safely call back into the Pool.
+func (p *Pool[K]) Range(f func(id K, sm fsm.StateMachine) bool) {
+	for _, sh := range p.shards {
+		sh.mu.RLock()
+		snapshot := make(map[K]fsm.StateMachine, len(sh.sms))
+		for id, sm := range sh.sms {
+			snapshot[id] = sm
+		}
+		sh.mu.RUnlock()
+
+		for id, sm := range snapshot {
+			if !f(id, sm) {
+				return
+			}
+		}
+	}
+}
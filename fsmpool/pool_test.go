@@ -0,0 +1,83 @@
+package fsmpool
+
+import (
+	"sync"
+	"testing"
+
+	fsm "github.com/caibirdme/fsm-go"
+)
+
+type testEvent struct{ t fsm.EventType }
+
+func (e testEvent) Type() fsm.EventType { return e.t }
+func (e testEvent) Unwrap() interface{} { return nil }
+
+const (
+	stateA fsm.StateNode = iota
+	stateB
+)
+
+const eventGo fsm.EventType = 1
+
+func testEdges() []fsm.TransEdge {
+	return []fsm.TransEdge{{From: stateA, Event: eventGo, To: stateB}}
+}
+
+func TestPoolEmitBuildsLazilyAndTransitions(t *testing.T) {
+	p := New[string](testEdges(), nil, nil, nil)
+
+	if st, ok := p.CurrentState("a"); ok {
+		t.Fatalf("CurrentState before first Emit = %v, %v, want !ok", st, ok)
+	}
+	if !p.Emit("a", testEvent{t: eventGo}) {
+		t.Fatal("Emit failed")
+	}
+	if st, ok := p.CurrentState("a"); !ok || st != stateB {
+		t.Fatalf("CurrentState(\"a\") = %v, %v, want stateB, true", st, ok)
+	}
+}
+
+func TestPoolEmitConcurrentSameID(t *testing.T) {
+	p := New[string](testEdges(), nil, nil, nil)
+
+	const n = 64
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			p.Emit("shared", testEvent{t: eventGo})
+		}()
+	}
+	wg.Wait()
+
+	if st, ok := p.CurrentState("shared"); !ok || st != stateB {
+		t.Fatalf("CurrentState(\"shared\") = %v, %v, want stateB, true", st, ok)
+	}
+}
+
+func TestPoolDeleteRemovesMachine(t *testing.T) {
+	p := New[string](testEdges(), nil, nil, nil)
+	p.Emit("a", testEvent{t: eventGo})
+	p.Delete("a")
+
+	if _, ok := p.CurrentState("a"); ok {
+		t.Fatal("CurrentState after Delete should report !ok")
+	}
+}
+
+func TestPoolRange(t *testing.T) {
+	p := New[string](testEdges(), nil, nil, nil)
+	p.Emit("a", testEvent{t: eventGo})
+	p.Emit("b", testEvent{t: eventGo})
+
+	seen := map[string]fsm.StateNode{}
+	p.Range(func(id string, sm fsm.StateMachine) bool {
+		seen[id] = sm.CurrentState()
+		return true
+	})
+
+	if len(seen) != 2 || seen["a"] != stateB || seen["b"] != stateB {
+		t.Fatalf("Range saw %v, want a and b both at stateB", seen)
+	}
+}
@@ -0,0 +1,172 @@
+package fsm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Edge is the generic counterpart of TransEdge: state From receiving event
+// Event moves to state To. Callback, if set, gates the transition and
+// receives the caller-supplied context D directly instead of an opaque
+// Event.Unwrap() interface{}.
+type Edge[S comparable, E comparable, D any] struct {
+	From     S
+	Event    E
+	To       S
+	Callback func(D) bool
+}
+
+// HookFn is the generic counterpart of HookFunc.
+type HookFn[D any] func(D) error
+
+// CallbackSet is the generic counterpart of Callbacks.
+type CallbackSet[D any] map[string]HookFn[D]
+
+// TransitionErrorG is the generic counterpart of TransitionError.
+type TransitionErrorG[E comparable] struct {
+	Kind  TransitionErrorKind
+	Event E
+	Err   error
+}
+
+func (e *TransitionErrorG[E]) Error() string {
+	switch e.Kind {
+	case ErrKindCanceled:
+		if e.Err != nil {
+			return "fsm: transition canceled: " + e.Err.Error()
+		}
+		return "fsm: transition canceled"
+	case ErrKindAsync:
+		return "fsm: transition already in progress"
+	default:
+		return "fsm: no transition for current state and event"
+	}
+}
+
+func (e *TransitionErrorG[E]) Unwrap() error { return e.Err }
+
+func beforeEventKeyG[E comparable](e E) string { return "before_" + fmt.Sprint(e) }
+func leaveStateKeyG[S comparable](s S) string  { return "leave_" + fmt.Sprint(s) }
+func enterStateKeyG[S comparable](s S) string  { return "enter_" + fmt.Sprint(s) }
+func afterEventKeyG[E comparable](e E) string  { return "after_" + fmt.Sprint(e) }
+
+type genericStateInfo[S comparable, D any] struct {
+	state S
+	cb    func(D) bool
+}
+
+// FSM is a generically typed finite state machine: S is the state key type,
+// E the event key type and D the user data/context delivered to every
+// callback. It mirrors StateMachine but lets callers use their own enum
+// types (e.g. type OrderState string) without casting through StateNode/
+// EventType, and lets callbacks receive a strongly typed D instead of
+// Event.Unwrap() interface{}. StateMachine/StateNode/EventType remain a
+// thin wrapper around FSM[StateNode, EventType, Event] for backward
+// compatibility.
+type FSM[S comparable, E comparable, D any] struct {
+	graph     map[S]map[E]genericStateInfo[S, D]
+	current   S
+	ignore    func(E, D) bool
+	callbacks CallbackSet[D]
+}
+
+// NewGenericFSM is the constructor for FSM[S, E, D]. our fsm will start at
+// `st` state. If ignore isn't nil, it will be invoked when emit an
+// unacceptable event for current state. callbacks registers the
+// before/leave/enter/after lifecycle hooks fired during Emit; it may be
+// nil.
+func NewGenericFSM[S comparable, E comparable, D any](st S, edges []Edge[S, E, D], ignore func(E, D) bool, callbacks CallbackSet[D]) (*FSM[S, E, D], error) {
+	m := &FSM[S, E, D]{
+		graph:     make(map[S]map[E]genericStateInfo[S, D]),
+		current:   st,
+		ignore:    ignore,
+		callbacks: callbacks,
+	}
+	for _, edge := range edges {
+		transTable, ok := m.graph[edge.From]
+		if !ok {
+			transTable = make(map[E]genericStateInfo[S, D])
+			m.graph[edge.From] = transTable
+		}
+		if _, ok := transTable[edge.Event]; ok {
+			return nil, errors.New("invalid fsm")
+		}
+		transTable[edge.Event] = genericStateInfo[S, D]{state: edge.To, cb: edge.Callback}
+	}
+	return m, nil
+}
+
+// Emit emits event carrying data and makes the transformation occur, thread
+// unsafe. If state transfer successfully, return true, or return false.
+func (m *FSM[S, E, D]) Emit(event E, data D) bool {
+	return m.EmitErr(event, data) == nil
+}
+
+// EmitErr is like Emit but reports why a transition didn't happen as a
+// *TransitionErrorG[E] instead of collapsing every failure into false.
+func (m *FSM[S, E, D]) EmitErr(event E, data D) error {
+	nextState, ok := m.graph[m.current][event]
+	if !ok {
+		if m.ignore != nil {
+			m.ignore(event, data)
+		}
+		return &TransitionErrorG[E]{Kind: ErrKindNoTransition, Event: event}
+	}
+
+	if err := m.fire(CallbackBeforeEvent, data); err != nil {
+		return &TransitionErrorG[E]{Kind: ErrKindCanceled, Event: event, Err: err}
+	}
+	if err := m.fire(beforeEventKeyG(event), data); err != nil {
+		return &TransitionErrorG[E]{Kind: ErrKindCanceled, Event: event, Err: err}
+	}
+
+	from := m.current
+	state, allowTrans := nextState.state, nextState.cb
+	if allowTrans != nil && !allowTrans(data) {
+		return &TransitionErrorG[E]{Kind: ErrKindCanceled, Event: event}
+	}
+
+	if err := m.fire(CallbackLeaveState, data); err != nil {
+		return &TransitionErrorG[E]{Kind: ErrKindCanceled, Event: event, Err: err}
+	}
+	if err := m.fire(leaveStateKeyG(from), data); err != nil {
+		return &TransitionErrorG[E]{Kind: ErrKindCanceled, Event: event, Err: err}
+	}
+
+	m.current = state
+
+	// enter_state/after_event hooks observe a committed transition, so their
+	// errors are not propagated: there's nothing left to cancel.
+	m.fire(CallbackEnterState, data)
+	m.fire(enterStateKeyG(state), data)
+	m.fire(CallbackAfterEvent, data)
+	m.fire(afterEventKeyG(event), data)
+
+	return nil
+}
+
+func (m *FSM[S, E, D]) fire(key string, data D) error {
+	if m.callbacks == nil {
+		return nil
+	}
+	if hook, ok := m.callbacks[key]; ok {
+		return hook(data)
+	}
+	return nil
+}
+
+// CurrentState returns the current state.
+func (m *FSM[S, E, D]) CurrentState() S {
+	return m.current
+}
+
+// Edges returns the transition table the machine was built from.
+func (m *FSM[S, E, D]) Edges() []Edge[S, E, D] {
+	edges := make([]Edge[S, E, D], 0, len(m.graph))
+	for from, transTable := range m.graph {
+		for event, info := range transTable {
+			edges = append(edges, Edge[S, E, D]{From: from, Event: event, To: info.state, Callback: info.cb})
+		}
+	}
+	return edges
+}
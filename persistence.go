@@ -0,0 +1,43 @@
+package fsm
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// decodeSnapshot recovers the StateNode a Snapshot call encoded.
+func decodeSnapshot(snapshot []byte) (StateNode, error) {
+	raw, err := strconv.Atoi(string(snapshot))
+	if err != nil {
+		return 0, fmt.Errorf("fsm: invalid snapshot: %w", err)
+	}
+	return StateNode(raw), nil
+}
+
+// RestoreFSM rebuilds a bare StateMachine from a snapshot previously
+// returned by Snapshot, so a long-lived FSM (an order workflow, a protocol
+// session) can survive a process restart. edges, ignore, callbacks and
+// onStateChange are supplied the same way NewFSM takes them - only the
+// current state travels through the snapshot, the transition table and
+// hooks are the caller's to keep around (e.g. as package level vars).
+//
+// RestoreFSM always yields the plain NewFSM variant: it doesn't carry over
+// thread-safety or state timeouts. Use RestoreSafeFSM or
+// RestoreFSMWithTimeout to restore into those variants instead.
+func RestoreFSM(snapshot []byte, edges []TransEdge, ignore Callback, callbacks Callbacks, onStateChange StateChangeHook) (StateMachine, error) {
+	st, err := decodeSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return NewFSM(st, edges, ignore, callbacks, onStateChange)
+}
+
+// RestoreSafeFSM is like RestoreFSM but rebuilds into a NewSafeFSM machine,
+// for a snapshot taken from one.
+func RestoreSafeFSM(snapshot []byte, edges []TransEdge, ignore Callback, callbacks Callbacks, onStateChange StateChangeHook) (StateMachine, error) {
+	st, err := decodeSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return NewSafeFSM(st, edges, ignore, callbacks, onStateChange)
+}
@@ -0,0 +1,156 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRestoreFSMRoundTrip(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+	edges := []TransEdge{{From: StateA, Event: EventGo, To: StateB}}
+
+	sm, err := NewFSM(StateA, edges, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+	if !sm.Emit(testEvent{t: EventGo}) {
+		t.Fatal("Emit failed")
+	}
+
+	restored, err := RestoreFSM(sm.Snapshot(), edges, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RestoreFSM: %v", err)
+	}
+	if restored.CurrentState() != StateB {
+		t.Fatalf("CurrentState() = %v, want StateB", restored.CurrentState())
+	}
+}
+
+// TestRestoreFSMCarriesOverCallbacks checks that RestoreFSM threads its
+// callbacks/onStateChange arguments into the rebuilt machine instead of
+// silently dropping lifecycle hooks on restore.
+func TestRestoreFSMCarriesOverCallbacks(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+		StateC
+	)
+	const EventGo EventType = 1
+	edges := []TransEdge{
+		{From: StateA, Event: EventGo, To: StateB},
+		{From: StateB, Event: EventGo, To: StateC},
+	}
+
+	var entered []StateNode
+	callbacks := Callbacks{
+		CallbackEnterState: func(Event) error { return nil },
+	}
+	var changed []StateNode
+	onStateChange := func(_, to StateNode, _ Event) { changed = append(changed, to) }
+
+	sm, err := NewFSM(StateA, edges, nil, callbacks, onStateChange)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+	if !sm.Emit(testEvent{t: EventGo}) {
+		t.Fatal("Emit failed")
+	}
+
+	restoreEntered := Callbacks{
+		CallbackEnterState: func(Event) error {
+			entered = append(entered, StateC)
+			return nil
+		},
+	}
+	restored, err := RestoreFSM(sm.Snapshot(), edges, nil, restoreEntered, onStateChange)
+	if err != nil {
+		t.Fatalf("RestoreFSM: %v", err)
+	}
+	if !restored.Emit(testEvent{t: EventGo}) {
+		t.Fatal("Emit on restored machine failed")
+	}
+	if len(entered) != 1 || entered[0] != StateC {
+		t.Fatalf("enter_state callback did not fire on restored machine, got %v", entered)
+	}
+	if len(changed) != 2 || changed[0] != StateB || changed[1] != StateC {
+		t.Fatalf("onStateChange = %v, want [StateB, StateC]", changed)
+	}
+}
+
+func TestRestoreSafeFSMRoundTrip(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+	edges := []TransEdge{{From: StateA, Event: EventGo, To: StateB}}
+
+	sm, err := NewSafeFSM(StateA, edges, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewSafeFSM: %v", err)
+	}
+	if !sm.Emit(testEvent{t: EventGo}) {
+		t.Fatal("Emit failed")
+	}
+
+	restored, err := RestoreSafeFSM(sm.Snapshot(), edges, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RestoreSafeFSM: %v", err)
+	}
+	if restored.CurrentState() != StateB {
+		t.Fatalf("CurrentState() = %v, want StateB", restored.CurrentState())
+	}
+	// RestoreSafeFSM must actually produce a safe (concurrency-guarded)
+	// machine, not silently fall back to the bare one.
+	if _, ok := restored.(*safeFSM); !ok {
+		t.Fatalf("RestoreSafeFSM returned %T, want *safeFSM", restored)
+	}
+}
+
+func TestRestoreFSMWithTimeoutRoundTrip(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+		StateTimedOut
+	)
+	const (
+		EventGo      EventType = 1
+		EventTimeout EventType = 2
+	)
+	edges := []TransEdge{{From: StateA, Event: EventGo, To: StateB}}
+	timeouts := map[StateNode]StateTimeout{
+		StateB: {Duration: 20 * time.Millisecond, OnTimeoutEvent: EventTimeout},
+	}
+
+	sm, err := NewFSMWithTimeout(StateA, edges, nil, nil, timeouts, nil)
+	if err != nil {
+		t.Fatalf("NewFSMWithTimeout: %v", err)
+	}
+	if !sm.Emit(testEvent{t: EventGo}) {
+		t.Fatal("Emit failed")
+	}
+	snap := sm.Snapshot()
+	sm.Close()
+
+	restored, err := RestoreFSMWithTimeout(snap, edges, nil, nil, timeouts, nil)
+	if err != nil {
+		t.Fatalf("RestoreFSMWithTimeout: %v", err)
+	}
+	defer restored.Close()
+
+	if restored.CurrentState() != StateB {
+		t.Fatalf("CurrentState() = %v, want StateB", restored.CurrentState())
+	}
+	// The timeout must carry over: left alone, StateB should time out into
+	// StateTimedOut - but there's no such edge, so the timeout event instead
+	// fires with no matching transition and current state stays StateB.
+	// What matters here is that the restored machine is actually the
+	// timeout-aware variant, not a bare one.
+	if _, ok := restored.(*timeoutFSM); !ok {
+		t.Fatalf("RestoreFSMWithTimeout returned %T, want *timeoutFSM", restored)
+	}
+}
@@ -0,0 +1,79 @@
+package fsm
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+)
+
+// ErrTransitionInProgress is the sentinel error wrapped in a *TransitionError
+// with Kind ErrKindAsync, returned when Emit is called while another Emit on
+// the same safe FSM is still running, e.g. re-entrantly from inside a
+// Callback or lifecycle hook.
+var ErrTransitionInProgress = errors.New("fsm: transition already in progress")
+
+// NewSafeFSM is like NewFSM but the returned StateMachine may be used from
+// multiple goroutines: Emit refuses to run concurrently with itself rather
+// than racing - whether the overlap comes from another goroutine or from a
+// Callback/hook calling back into Emit re-entrantly, it fails fast with a
+// *TransitionError of Kind ErrKindAsync instead of corrupting state.
+//
+// CurrentState and Snapshot are served from an atomic cache updated only
+// after a transition fully completes, rather than by locking around the
+// inner machine: the firing CAS already guarantees at most one EmitErr call
+// is ever mutating the inner machine at a time, so a plain mutex around
+// CurrentState would either be safe but deadlock-prone (a hook calling back
+// into CurrentState re-entrantly can't take a lock its own Emit is holding -
+// RWMutex isn't reentrant) or racy (falling back to an unlocked read whenever
+// the lock is held races the inner machine's write for real, not just in the
+// reentrant case, whenever a transition happens to be genuinely in flight on
+// another goroutine). The atomic cache sidesteps both: it's always race-free,
+// at the cost of a hook reading CurrentState mid-transition seeing the
+// pre-transition value rather than the inner machine's already-mutated one.
+// Edges needs neither a lock nor the cache: the transition table is built
+// once in NewGenericFSM and never mutated afterward.
+func NewSafeFSM(st StateNode, edges []TransEdge, ignore Callback, callbacks Callbacks, onStateChange StateChangeHook) (StateMachine, error) {
+	inner, err := NewFSM(st, edges, ignore, callbacks, onStateChange)
+	if err != nil {
+		return nil, err
+	}
+	s := &safeFSM{sm: inner}
+	s.current.Store(int64(inner.CurrentState()))
+	return s, nil
+}
+
+type safeFSM struct {
+	sm      StateMachine
+	firing  uint32
+	current atomic.Int64
+}
+
+func (s *safeFSM) Emit(e Event) bool {
+	return s.EmitErr(e) == nil
+}
+
+func (s *safeFSM) EmitErr(e Event) error {
+	if !atomic.CompareAndSwapUint32(&s.firing, 0, 1) {
+		return &TransitionError{Kind: ErrKindAsync, Event: e.Type(), Err: ErrTransitionInProgress, OriginalEvent: e}
+	}
+	defer atomic.StoreUint32(&s.firing, 0)
+
+	err := s.sm.EmitErr(e)
+	s.current.Store(int64(s.sm.CurrentState()))
+	return err
+}
+
+func (s *safeFSM) CurrentState() StateNode {
+	return StateNode(s.current.Load())
+}
+
+func (s *safeFSM) Edges() []TransEdge {
+	return s.sm.Edges()
+}
+
+// Close is a no-op: safeFSM holds no background resources.
+func (s *safeFSM) Close() error { return nil }
+
+func (s *safeFSM) Snapshot() []byte {
+	return []byte(strconv.Itoa(int(s.current.Load())))
+}
@@ -0,0 +1,53 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+)
+
+type testEvent struct{ t EventType }
+
+func (e testEvent) Type() EventType     { return e.t }
+func (e testEvent) Unwrap() interface{} { return nil }
+
+// TestSafeFSMReentrantReadDoesNotDeadlock guards against a hook reading back
+// CurrentState/Edges on the same machine from inside Emit: since RWMutex
+// isn't reentrant, a lock around those reads would deadlock here. The reads
+// must return and the Emit must still complete the transition.
+func TestSafeFSMReentrantReadDoesNotDeadlock(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	var sm StateMachine
+	callbacks := Callbacks{
+		CallbackEnterState: func(Event) error {
+			_ = sm.CurrentState()
+			_ = sm.Edges()
+			return nil
+		},
+	}
+
+	sm, err := NewSafeFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, callbacks, nil)
+	if err != nil {
+		t.Fatalf("NewSafeFSM: %v", err)
+	}
+
+	done := make(chan bool, 1)
+	go func() { done <- sm.Emit(testEvent{t: EventGo}) }()
+
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatalf("Emit returned false")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Emit deadlocked when a hook read CurrentState/Edges re-entrantly")
+	}
+
+	if got := sm.CurrentState(); got != StateB {
+		t.Fatalf("CurrentState() = %v, want %v", got, StateB)
+	}
+}
@@ -0,0 +1,119 @@
+package fsm
+
+import (
+	"sync"
+	"time"
+)
+
+// StateTimeout configures an automatic transition out of a state: if Emit
+// isn't called with a transitioning event within Duration of entering the
+// state, OnTimeoutEvent is emitted on the machine's behalf, mirroring
+// carbynestack's WhenStateTimeout. Useful for protocol FSMs - handshake
+// timeouts, session idle, etc.
+type StateTimeout struct {
+	Duration       time.Duration
+	OnTimeoutEvent EventType
+}
+
+// timeoutEvent is the Event fed back into the wrapped StateMachine when a
+// StateTimeout fires.
+type timeoutEvent struct {
+	event EventType
+}
+
+func (e timeoutEvent) Type() EventType     { return e.event }
+func (e timeoutEvent) Unwrap() interface{} { return nil }
+
+// NewFSMWithTimeout is like NewSafeFSM, but states listed in timeouts start
+// a timer as soon as they're entered; if no transitioning event arrives
+// before it fires, timeouts[state].OnTimeoutEvent is emitted automatically.
+// Call Close to cancel any outstanding timer, e.g. when the owning session
+// ends.
+func NewFSMWithTimeout(st StateNode, edges []TransEdge, ignore Callback, callbacks Callbacks, timeouts map[StateNode]StateTimeout, onStateChange StateChangeHook) (StateMachine, error) {
+	sm, err := NewSafeFSM(st, edges, ignore, callbacks, onStateChange)
+	if err != nil {
+		return nil, err
+	}
+	t := &timeoutFSM{sm: sm, timeouts: timeouts}
+	t.rearm()
+	return t, nil
+}
+
+// RestoreFSMWithTimeout is like RestoreFSM but rebuilds into a
+// NewFSMWithTimeout machine, for a snapshot taken from one - e.g. resuming a
+// protocol session's handshake/idle timeout across a process restart.
+func RestoreFSMWithTimeout(snapshot []byte, edges []TransEdge, ignore Callback, callbacks Callbacks, timeouts map[StateNode]StateTimeout, onStateChange StateChangeHook) (StateMachine, error) {
+	st, err := decodeSnapshot(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return NewFSMWithTimeout(st, edges, ignore, callbacks, timeouts, onStateChange)
+}
+
+type timeoutFSM struct {
+	sm       StateMachine
+	timeouts map[StateNode]StateTimeout
+
+	mu     sync.Mutex
+	timer  *time.Timer
+	closed bool
+}
+
+func (t *timeoutFSM) Emit(e Event) bool {
+	return t.EmitErr(e) == nil
+}
+
+func (t *timeoutFSM) EmitErr(e Event) error {
+	err := t.sm.EmitErr(e)
+	if err == nil {
+		t.rearm()
+	}
+	return err
+}
+
+func (t *timeoutFSM) CurrentState() StateNode {
+	return t.sm.CurrentState()
+}
+
+func (t *timeoutFSM) Edges() []TransEdge {
+	return t.sm.Edges()
+}
+
+func (t *timeoutFSM) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	t.stopTimerLocked()
+	return t.sm.Close()
+}
+
+// rearm cancels any pending timer and, if the current state has a
+// StateTimeout configured, starts a fresh one for it.
+func (t *timeoutFSM) rearm() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	t.stopTimerLocked()
+
+	cfg, ok := t.timeouts[t.sm.CurrentState()]
+	if !ok {
+		return
+	}
+	t.timer = time.AfterFunc(cfg.Duration, func() {
+		t.sm.Emit(timeoutEvent{event: cfg.OnTimeoutEvent})
+		t.rearm()
+	})
+}
+
+func (t *timeoutFSM) stopTimerLocked() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *timeoutFSM) Snapshot() []byte {
+	return t.sm.Snapshot()
+}
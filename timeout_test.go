@@ -0,0 +1,124 @@
+package fsm
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFSMWithTimeoutFiresAutomatically checks that a state's configured
+// timeout transitions the machine on its own once the duration elapses.
+func TestFSMWithTimeoutFiresAutomatically(t *testing.T) {
+	const (
+		Idle StateNode = iota
+		Waiting
+		Expired
+	)
+	const (
+		EventStart   EventType = 1
+		EventTimeout EventType = 2
+	)
+
+	sm, err := NewFSMWithTimeout(Idle, []TransEdge{
+		{From: Idle, Event: EventStart, To: Waiting},
+		{From: Waiting, Event: EventTimeout, To: Expired},
+	}, nil, nil, map[StateNode]StateTimeout{
+		Waiting: {Duration: 20 * time.Millisecond, OnTimeoutEvent: EventTimeout},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSMWithTimeout: %v", err)
+	}
+	defer sm.Close()
+
+	if !sm.Emit(testEvent{t: EventStart}) {
+		t.Fatal("Emit(EventStart) failed")
+	}
+
+	deadline := time.After(500 * time.Millisecond)
+	for sm.CurrentState() != Expired {
+		select {
+		case <-deadline:
+			t.Fatalf("state timeout never fired, stuck at %v", sm.CurrentState())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+// TestFSMWithTimeoutCloseCancelsTimer checks that Close stops the pending
+// timer so it can't fire (and race with a concurrent caller) after the
+// machine is done with.
+func TestFSMWithTimeoutCloseCancelsTimer(t *testing.T) {
+	const (
+		Idle StateNode = iota
+		Waiting
+		Expired
+	)
+	const (
+		EventStart   EventType = 1
+		EventTimeout EventType = 2
+	)
+
+	sm, err := NewFSMWithTimeout(Idle, []TransEdge{
+		{From: Idle, Event: EventStart, To: Waiting},
+		{From: Waiting, Event: EventTimeout, To: Expired},
+	}, nil, nil, map[StateNode]StateTimeout{
+		Waiting: {Duration: 10 * time.Millisecond, OnTimeoutEvent: EventTimeout},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSMWithTimeout: %v", err)
+	}
+
+	if !sm.Emit(testEvent{t: EventStart}) {
+		t.Fatal("Emit(EventStart) failed")
+	}
+	if err := sm.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if sm.CurrentState() != Waiting {
+		t.Fatalf("CurrentState() = %v, want Waiting: Close should have canceled the pending timer", sm.CurrentState())
+	}
+}
+
+// TestFSMWithTimeoutRearmsOnTransition checks that entering a new state
+// resets the timer to that state's own configured timeout rather than
+// reusing the previous state's.
+func TestFSMWithTimeoutRearmsOnTransition(t *testing.T) {
+	const (
+		Idle StateNode = iota
+		Waiting
+		NoTimeout
+		Expired
+	)
+	const (
+		EventStart   EventType = 1
+		EventAdvance EventType = 2
+		EventTimeout EventType = 3
+	)
+
+	sm, err := NewFSMWithTimeout(Idle, []TransEdge{
+		{From: Idle, Event: EventStart, To: Waiting},
+		{From: Waiting, Event: EventAdvance, To: NoTimeout},
+		{From: Waiting, Event: EventTimeout, To: Expired},
+	}, nil, nil, map[StateNode]StateTimeout{
+		Waiting: {Duration: 15 * time.Millisecond, OnTimeoutEvent: EventTimeout},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewFSMWithTimeout: %v", err)
+	}
+	defer sm.Close()
+
+	if !sm.Emit(testEvent{t: EventStart}) {
+		t.Fatal("Emit(EventStart) failed")
+	}
+	if !sm.Emit(testEvent{t: EventAdvance}) {
+		t.Fatal("Emit(EventAdvance) failed")
+	}
+
+	// NoTimeout has no configured StateTimeout, so the Waiting timer must
+	// not fire and drag the machine into Expired behind our back.
+	time.Sleep(50 * time.Millisecond)
+	if sm.CurrentState() != NoTimeout {
+		t.Fatalf("CurrentState() = %v, want NoTimeout: stale timer fired after leaving Waiting", sm.CurrentState())
+	}
+}
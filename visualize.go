@@ -0,0 +1,97 @@
+package fsm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// VisualizeFormat selects the output format for Visualize.
+type VisualizeFormat int
+
+const (
+	// FormatMermaid renders a mermaid stateDiagram-v2 block.
+	FormatMermaid VisualizeFormat = iota
+	// FormatGraphvizDOT renders a Graphviz DOT digraph.
+	FormatGraphvizDOT
+)
+
+// StateNodeStringer names a StateNode for visualization output. The zero
+// value falls back to the node's integer value.
+type StateNodeStringer func(StateNode) string
+
+// EventTypeStringer names an EventType for visualization output. The zero
+// value falls back to the event's integer value.
+type EventTypeStringer func(EventType) string
+
+// VisualizeOption configures Visualize.
+type VisualizeOption func(*visualizeConfig)
+
+type visualizeConfig struct {
+	stateString StateNodeStringer
+	eventString EventTypeStringer
+}
+
+// WithStateNodeStringer names states in the visualization output, since
+// StateNode is otherwise just an int.
+func WithStateNodeStringer(f StateNodeStringer) VisualizeOption {
+	return func(c *visualizeConfig) {
+		if f != nil {
+			c.stateString = f
+		}
+	}
+}
+
+// WithEventTypeStringer names events in the visualization output, since
+// EventType is otherwise just an int.
+func WithEventTypeStringer(f EventTypeStringer) VisualizeOption {
+	return func(c *visualizeConfig) {
+		if f != nil {
+			c.eventString = f
+		}
+	}
+}
+
+// Visualize renders sm's transition table as a string in the given format,
+// so it can be dropped into docs or a CI-generated diagram. The machine's
+// current state is rendered as the diagram's starting state.
+func Visualize(sm StateMachine, format VisualizeFormat, opts ...VisualizeOption) (string, error) {
+	cfg := &visualizeConfig{
+		stateString: func(s StateNode) string { return strconv.Itoa(int(s)) },
+		eventString: func(e EventType) string { return strconv.Itoa(int(e)) },
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	switch format {
+	case FormatMermaid:
+		return visualizeMermaid(sm, cfg), nil
+	case FormatGraphvizDOT:
+		return visualizeGraphvizDOT(sm, cfg), nil
+	default:
+		return "", fmt.Errorf("fsm: unknown visualize format %d", format)
+	}
+}
+
+func visualizeMermaid(sm StateMachine, cfg *visualizeConfig) string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	fmt.Fprintf(&b, "    [*] --> %s\n", cfg.stateString(sm.CurrentState()))
+	for _, edge := range sm.Edges() {
+		fmt.Fprintf(&b, "    %s --> %s : %s\n", cfg.stateString(edge.From), cfg.stateString(edge.To), cfg.eventString(edge.Event))
+	}
+	return b.String()
+}
+
+func visualizeGraphvizDOT(sm StateMachine, cfg *visualizeConfig) string {
+	var b strings.Builder
+	b.WriteString("digraph fsm {\n")
+	b.WriteString("    rankdir=LR;\n")
+	fmt.Fprintf(&b, "    %q [shape=doublecircle];\n", cfg.stateString(sm.CurrentState()))
+	for _, edge := range sm.Edges() {
+		fmt.Fprintf(&b, "    %q -> %q [label=%q];\n", cfg.stateString(edge.From), cfg.stateString(edge.To), cfg.eventString(edge.Event))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
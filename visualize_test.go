@@ -0,0 +1,151 @@
+package fsm
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestVisualizeMermaidExactOutput checks the exact mermaid stateDiagram-v2
+// text Visualize produces for a small machine, not just that it contains the
+// right substrings.
+func TestVisualizeMermaidExactOutput(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	sm, err := NewFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	got, err := Visualize(sm, FormatMermaid)
+	if err != nil {
+		t.Fatalf("Visualize: %v", err)
+	}
+	want := "stateDiagram-v2\n" +
+		"    [*] --> 0\n" +
+		"    0 --> 1 : 1\n"
+	if got != want {
+		t.Fatalf("Visualize(FormatMermaid) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestVisualizeGraphvizDOTExactOutput checks the exact DOT text Visualize
+// produces for the same machine.
+func TestVisualizeGraphvizDOTExactOutput(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+	)
+	const EventGo EventType = 1
+
+	sm, err := NewFSM(StateA, []TransEdge{{From: StateA, Event: EventGo, To: StateB}}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	got, err := Visualize(sm, FormatGraphvizDOT)
+	if err != nil {
+		t.Fatalf("Visualize: %v", err)
+	}
+	want := "digraph fsm {\n" +
+		"    rankdir=LR;\n" +
+		"    \"0\" [shape=doublecircle];\n" +
+		"    \"0\" -> \"1\" [label=\"1\"];\n" +
+		"}\n"
+	if got != want {
+		t.Fatalf("Visualize(FormatGraphvizDOT) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestVisualizeStringerOptions checks that WithStateNodeStringer and
+// WithEventTypeStringer substitute names for the raw int values.
+func TestVisualizeStringerOptions(t *testing.T) {
+	const (
+		StateOpen StateNode = iota
+		StateClosed
+	)
+	const EventClose EventType = 1
+
+	sm, err := NewFSM(StateOpen, []TransEdge{{From: StateOpen, Event: EventClose, To: StateClosed}}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	stateName := func(s StateNode) string {
+		if s == StateClosed {
+			return "closed"
+		}
+		return "open"
+	}
+	eventName := func(e EventType) string {
+		if e == EventClose {
+			return "close"
+		}
+		return "unknown"
+	}
+
+	got, err := Visualize(sm, FormatMermaid, WithStateNodeStringer(stateName), WithEventTypeStringer(eventName))
+	if err != nil {
+		t.Fatalf("Visualize: %v", err)
+	}
+	want := "stateDiagram-v2\n" +
+		"    [*] --> open\n" +
+		"    open --> closed : close\n"
+	if got != want {
+		t.Fatalf("Visualize with stringers =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestVisualizeUnknownFormat checks that an unrecognized VisualizeFormat
+// reports an error instead of silently returning an empty string.
+func TestVisualizeUnknownFormat(t *testing.T) {
+	sm, err := NewFSM(StateNode(0), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+	if _, err := Visualize(sm, VisualizeFormat(99)); err == nil {
+		t.Fatal("Visualize with an unknown format should return an error")
+	}
+}
+
+// TestVisualizeWildcardEdgesRenderExpandedNotWildcard documents a real
+// interaction between Visualize and expandWildcardEdges (see fsm.go):
+// NewFSM expands an AnyState edge into one concrete edge per reachable state
+// before the machine ever stores its transition table, so Edges() - and
+// therefore Visualize - has no way to tell an originally-wildcard edge apart
+// from a hand-written concrete one. A 3-state machine with a single "abort
+// from anywhere" wildcard edge renders as 3 separate lines, not one line
+// using a wildcard source.
+func TestVisualizeWildcardEdgesRenderExpandedNotWildcard(t *testing.T) {
+	const (
+		StateIdle StateNode = iota
+		StateRunning
+		StateAborted
+	)
+	const (
+		EventStart EventType = 1
+		EventAbort EventType = 2
+	)
+
+	sm, err := NewFSM(StateIdle, []TransEdge{
+		{From: StateIdle, Event: EventStart, To: StateRunning},
+		{From: AnyState, Event: EventAbort, To: StateAborted},
+	}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	got, err := Visualize(sm, FormatMermaid)
+	if err != nil {
+		t.Fatalf("Visualize: %v", err)
+	}
+
+	// One expanded "--> 2 : 2" line per reachable state (Idle, Running,
+	// Aborted itself), not a single wildcard-sourced line.
+	if n := strings.Count(got, "--> 2 : 2"); n != 3 {
+		t.Fatalf("Visualize rendered %d expanded abort edges, want 3 (one per state) in:\n%s", n, got)
+	}
+}
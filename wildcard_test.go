@@ -0,0 +1,83 @@
+package fsm
+
+import "testing"
+
+// TestAnyStateAppliesToWildcardTarget ensures a wildcard edge also applies
+// once the machine has reached the wildcard's own To state, even when that
+// state is never otherwise referenced by a concrete edge.
+func TestAnyStateAppliesToWildcardTarget(t *testing.T) {
+	const (
+		Idle StateNode = iota
+		Running
+		Aborted
+	)
+	const (
+		EventStart EventType = iota
+		EventAbort
+	)
+
+	sm, err := NewFSM(Idle, []TransEdge{
+		{From: Idle, Event: EventStart, To: Running},
+		{From: AnyState, Event: EventAbort, To: Aborted},
+	}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	if !sm.Emit(testEvent{t: EventAbort}) {
+		t.Fatalf("Abort from Idle failed")
+	}
+	if sm.CurrentState() != Aborted {
+		t.Fatalf("CurrentState() = %v, want Aborted", sm.CurrentState())
+	}
+
+	// The whole point of AnyState is "from any state" - including the
+	// wildcard's own target, which is never a concrete From/To anywhere else.
+	if !sm.Emit(testEvent{t: EventAbort}) {
+		t.Fatalf("Abort from Aborted failed: AnyState should cover its own target state")
+	}
+	if sm.CurrentState() != Aborted {
+		t.Fatalf("CurrentState() = %v, want Aborted", sm.CurrentState())
+	}
+}
+
+// TestNewFSMDuplicateWildcardEdges rejects two wildcard edges registered
+// for the same event.
+func TestNewFSMDuplicateWildcardEdges(t *testing.T) {
+	const StateA StateNode = iota
+	const EventAbort EventType = 1
+
+	_, err := NewFSM(StateA, []TransEdge{
+		{From: AnyState, Event: EventAbort, To: StateA},
+		{From: AnyState, Event: EventAbort, To: StateA},
+	}, nil, nil, nil)
+	if err == nil {
+		t.Fatal("expected error for duplicate wildcard edges, got nil")
+	}
+}
+
+// TestNewFSMConcreteEdgeWinsOverWildcard ensures a specific edge for a
+// (state, event) pair is preferred over a wildcard for the same event.
+func TestNewFSMConcreteEdgeWinsOverWildcard(t *testing.T) {
+	const (
+		StateA StateNode = iota
+		StateB
+		StateC
+	)
+	const EventReset EventType = 1
+
+	sm, err := NewFSM(StateA, []TransEdge{
+		{From: StateA, Event: EventReset, To: StateB},
+		{From: AnyState, Event: EventReset, To: StateC},
+	}, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewFSM: %v", err)
+	}
+
+	if !sm.Emit(testEvent{t: EventReset}) {
+		t.Fatal("Emit failed")
+	}
+	if sm.CurrentState() != StateB {
+		t.Fatalf("CurrentState() = %v, want StateB (concrete edge should win)", sm.CurrentState())
+	}
+}